@@ -0,0 +1,35 @@
+package buildserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/concourse/atc/api/present"
+)
+
+func (s *Server) GetCheck(w http.ResponseWriter, r *http.Request) {
+	logger := s.logger.Session("get-check")
+
+	checkID, err := strconv.Atoi(r.FormValue(":check_id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	check, found, err := s.checkFactory.GetCheck(checkID)
+	if err != nil {
+		logger.Error("failed-to-get-check", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(present.Check(check))
+}
@@ -0,0 +1,96 @@
+package buildserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/concourse/atc/db"
+)
+
+const buildEventsPollInterval = time.Second
+
+func (s *Server) SubmitEvent(build db.Build) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := s.logger.Session("submit-event")
+
+		var event db.BuildEvent
+		err := json.NewDecoder(r.Body).Decode(&event)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		event.BuildID = build.ID
+
+		err = build.SaveEvent(event)
+		if err != nil {
+			logger.Error("failed-to-save-event", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// BuildEvents streams the build's events as server-sent events. Once the
+// backlog is flushed it keeps polling for new events so a client can
+// tail a running build, stopping once the build reaches a terminal
+// status or the client disconnects.
+func (s *Server) BuildEvents(build db.Build) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := s.logger.Session("build-events")
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+
+		var lastEventID int
+		for {
+			events, err := build.EventsAfter(lastEventID)
+			if err != nil {
+				logger.Error("failed-to-get-events", err)
+				return
+			}
+
+			for _, event := range events {
+				payload, err := json.Marshal(event)
+				if err != nil {
+					logger.Error("failed-to-marshal-event", err)
+					return
+				}
+
+				fmt.Fprintf(w, "data: %s\n\n", payload)
+				lastEventID = event.EventID
+			}
+
+			if len(events) > 0 {
+				flusher.Flush()
+			}
+
+			found, err := build.Reload()
+			if err != nil {
+				logger.Error("failed-to-reload-build", err)
+				return
+			}
+
+			if !found || !build.IsRunning() {
+				return
+			}
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(buildEventsPollInterval):
+			}
+		}
+	}
+}
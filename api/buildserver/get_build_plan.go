@@ -0,0 +1,34 @@
+package buildserver
+
+import (
+	"net/http"
+
+	"github.com/concourse/atc/db"
+)
+
+// GetBuildPlan returns the build's public plan. If the build has no plan
+// materialized yet -- a one-off build before scheduling, or a build from
+// an engine that pre-dates the AddNonceAndPublicPlanToBuilds migration --
+// it responds 404 with no body, rather than serving an empty object, so
+// that clients can distinguish "no plan yet" from "empty plan".
+func (s *Server) GetBuildPlan(build db.Build) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := s.logger.Session("get-build-plan")
+
+		if !build.HasPlan() {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		plan, err := build.PublicPlan()
+		if err != nil {
+			logger.Error("failed-to-get-build-plan", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(plan)
+	}
+}
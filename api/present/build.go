@@ -0,0 +1,45 @@
+package present
+
+import (
+	"encoding/json"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/db"
+)
+
+func Build(build db.Build) atc.Build {
+	return atc.Build{
+		ID:           build.ID,
+		Name:         build.Name,
+		Status:       string(build.Status),
+		HasPlan:      build.HasPlan(),
+		StepStatuses: stepStatuses(build),
+	}
+}
+
+// stepStatuses aggregates the build's status events into a per-step status
+// summary, so that clients can render build progress without fetching
+// and replaying the full event stream.
+func stepStatuses(build db.Build) map[string]string {
+	events, err := build.StatusEvents()
+	if err != nil {
+		return nil
+	}
+
+	statuses := map[string]string{}
+	for _, event := range events {
+		var status struct {
+			Step   string `json:"step"`
+			Status string `json:"status"`
+		}
+
+		err = json.Unmarshal(event.Payload, &status)
+		if err != nil {
+			continue
+		}
+
+		statuses[status.Step] = status.Status
+	}
+
+	return statuses
+}
@@ -0,0 +1,22 @@
+package present
+
+import (
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/dbng"
+)
+
+func Check(check *dbng.Check) atc.Check {
+	presented := atc.Check{
+		ID:               check.ID,
+		ResourceConfigID: check.ResourceConfigID,
+		Status:           string(check.Status),
+		Plan:             check.Plan,
+		CreateTime:       check.CreateTime.Unix(),
+	}
+
+	if check.CheckError != nil {
+		presented.Error = check.CheckError.Error()
+	}
+
+	return presented
+}
@@ -0,0 +1,72 @@
+package creds
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheKey struct {
+	team     string
+	pipeline string
+	varName  string
+}
+
+type cacheEntry struct {
+	value   string
+	found   bool
+	expires time.Time
+}
+
+// CachedManager wraps a Manager with a TTL cache
+// keyed by (team, pipeline, var), so that interpolating the same var for
+// every build in a pipeline doesn't round-trip to the backing secret
+// store each time.
+type CachedManager struct {
+	manager Manager
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	entries map[cacheKey]cacheEntry
+
+	now func() time.Time
+}
+
+func NewCachedManager(manager Manager, config CacheConfig) *CachedManager {
+	return &CachedManager{
+		manager: manager,
+		ttl:     config.TTL,
+		entries: make(map[cacheKey]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+func (c *CachedManager) Name() string {
+	return c.manager.Name()
+}
+
+func (c *CachedManager) Get(team string, pipeline string, varName string) (string, bool, error) {
+	key := cacheKey{team: team, pipeline: pipeline, varName: varName}
+
+	c.mu.Lock()
+	entry, cached := c.entries[key]
+	c.mu.Unlock()
+
+	if cached && c.now().Before(entry.expires) {
+		return entry.value, entry.found, nil
+	}
+
+	value, found, err := c.manager.Get(team, pipeline, varName)
+	if err != nil {
+		return "", false, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{
+		value:   value,
+		found:   found,
+		expires: c.now().Add(c.ttl),
+	}
+	c.mu.Unlock()
+
+	return value, found, nil
+}
@@ -0,0 +1,27 @@
+package creds
+
+import "fmt"
+
+// CredhubManager resolves vars against a CredHub server.
+type CredhubManager struct {
+	Client CredhubClient
+}
+
+type CredhubClient interface {
+	GetLatestVersion(name string) (string, bool, error)
+}
+
+func (m CredhubManager) Name() string {
+	return "credhub"
+}
+
+func (m CredhubManager) Get(team string, pipeline string, varName string) (string, bool, error) {
+	if pipeline != "" {
+		value, found, err := m.Client.GetLatestVersion(fmt.Sprintf("/concourse/%s/%s/%s", team, pipeline, varName))
+		if err != nil || found {
+			return value, found, err
+		}
+	}
+
+	return m.Client.GetLatestVersion(fmt.Sprintf("/concourse/%s/%s", team, varName))
+}
@@ -0,0 +1,28 @@
+package creds
+
+import "fmt"
+
+// KubernetesManager resolves vars against Kubernetes secrets, namespaced
+// per team.
+type KubernetesManager struct {
+	Client KubernetesClient
+}
+
+type KubernetesClient interface {
+	GetSecret(namespace string, name string, key string) (string, bool, error)
+}
+
+func (m KubernetesManager) Name() string {
+	return "kubernetes"
+}
+
+func (m KubernetesManager) Get(team string, pipeline string, varName string) (string, bool, error) {
+	if pipeline != "" {
+		value, found, err := m.Client.GetSecret(team, fmt.Sprintf("concourse-%s", pipeline), varName)
+		if err != nil || found {
+			return value, found, err
+		}
+	}
+
+	return m.Client.GetSecret(team, "concourse", varName)
+}
@@ -0,0 +1,40 @@
+package creds
+
+import "time"
+
+// Manager resolves a `((var))` placeholder to its underlying secret value
+// for a given team and, optionally, pipeline. Implementations are backed
+// by Vault, AWS SSM, AWS Secrets Manager, CredHub, or Kubernetes secrets.
+//
+// Lookup follows the path convention `/concourse/<team>/<pipeline>/<var>`,
+// falling back to `/concourse/<team>/<var>` when no pipeline-scoped value
+// exists.
+type Manager interface {
+	Name() string
+	Get(team string, pipeline string, varName string) (string, bool, error)
+}
+
+// Managers is an ordered chain of Manager implementations. Get resolves a
+// var by trying each manager in turn and returning the first hit.
+type Managers []Manager
+
+func (managers Managers) Get(team string, pipeline string, varName string) (string, bool, error) {
+	for _, manager := range managers {
+		value, found, err := manager.Get(team, pipeline, varName)
+		if err != nil {
+			return "", false, err
+		}
+
+		if found {
+			return value, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// CacheConfig configures how long a resolved var is cached, keyed by
+// (team, pipeline, var), before a Manager is consulted again.
+type CacheConfig struct {
+	TTL time.Duration
+}
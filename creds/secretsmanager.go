@@ -0,0 +1,27 @@
+package creds
+
+import "fmt"
+
+// SecretsManagerManager resolves vars against AWS Secrets Manager.
+type SecretsManagerManager struct {
+	Client SecretsManagerClient
+}
+
+type SecretsManagerClient interface {
+	GetSecretValue(id string) (string, bool, error)
+}
+
+func (m SecretsManagerManager) Name() string {
+	return "secretsmanager"
+}
+
+func (m SecretsManagerManager) Get(team string, pipeline string, varName string) (string, bool, error) {
+	if pipeline != "" {
+		value, found, err := m.Client.GetSecretValue(fmt.Sprintf("/concourse/%s/%s/%s", team, pipeline, varName))
+		if err != nil || found {
+			return value, found, err
+		}
+	}
+
+	return m.Client.GetSecretValue(fmt.Sprintf("/concourse/%s/%s", team, varName))
+}
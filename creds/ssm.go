@@ -0,0 +1,27 @@
+package creds
+
+import "fmt"
+
+// SSMManager resolves vars against AWS Systems Manager Parameter Store.
+type SSMManager struct {
+	Client SSMClient
+}
+
+type SSMClient interface {
+	GetParameter(name string) (string, bool, error)
+}
+
+func (m SSMManager) Name() string {
+	return "ssm"
+}
+
+func (m SSMManager) Get(team string, pipeline string, varName string) (string, bool, error) {
+	if pipeline != "" {
+		value, found, err := m.Client.GetParameter(fmt.Sprintf("/concourse/%s/%s/%s", team, pipeline, varName))
+		if err != nil || found {
+			return value, found, err
+		}
+	}
+
+	return m.Client.GetParameter(fmt.Sprintf("/concourse/%s/%s", team, varName))
+}
@@ -0,0 +1,45 @@
+package creds
+
+import "fmt"
+
+// VaultManager resolves vars against a Vault KV backend using the
+// standard /concourse/<team>/<pipeline>/<var> path convention.
+type VaultManager struct {
+	Client VaultClient
+}
+
+// VaultClient is the subset of a Vault API client VaultManager needs,
+// kept narrow so tests can provide a fake without pulling in the real
+// Vault SDK.
+type VaultClient interface {
+	Read(path string) (map[string]interface{}, bool, error)
+}
+
+func (m VaultManager) Name() string {
+	return "vault"
+}
+
+func (m VaultManager) Get(team string, pipeline string, varName string) (string, bool, error) {
+	if pipeline != "" {
+		value, found, err := m.read(fmt.Sprintf("/concourse/%s/%s/%s", team, pipeline, varName))
+		if err != nil || found {
+			return value, found, err
+		}
+	}
+
+	return m.read(fmt.Sprintf("/concourse/%s/%s", team, varName))
+}
+
+func (m VaultManager) read(path string) (string, bool, error) {
+	secret, found, err := m.Client.Read(path)
+	if err != nil || !found {
+		return "", found, err
+	}
+
+	value, ok := secret["value"].(string)
+	if !ok {
+		return "", false, nil
+	}
+
+	return value, true, nil
+}
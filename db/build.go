@@ -0,0 +1,53 @@
+package db
+
+import "database/sql"
+
+type BuildStatus string
+
+const (
+	BuildStatusPending   BuildStatus = "pending"
+	BuildStatusStarted   BuildStatus = "started"
+	BuildStatusSucceeded BuildStatus = "succeeded"
+	BuildStatusFailed    BuildStatus = "failed"
+	BuildStatusErrored   BuildStatus = "errored"
+	BuildStatusAborted   BuildStatus = "aborted"
+)
+
+// Build is a single execution of a job, or a one-off task run through
+// `fly execute`.
+type Build struct {
+	ID     int
+	Name   string
+	Status BuildStatus
+
+	publicPlan []byte
+
+	conn *sql.DB
+}
+
+// NewBuild constructs a Build from already-fetched columns, for callers
+// (such as migration tests) scanning builds directly off a *sql.DB
+// rather than going through a factory.
+func NewBuild(id int, name string, status BuildStatus, publicPlan []byte) Build {
+	return Build{
+		ID:         id,
+		Name:       name,
+		Status:     status,
+		publicPlan: publicPlan,
+	}
+}
+
+// HasPlan reports whether the build has a public plan materialized yet.
+// One-off builds that have not been scheduled, and builds created by an
+// engine that pre-dates the AddNonceAndPublicPlanToBuilds migration, have
+// no public_plan to serve.
+func (b Build) HasPlan() bool {
+	return len(b.publicPlan) > 0
+}
+
+// PublicPlan returns the raw public_plan JSON for the build. Callers
+// should check HasPlan() first; PublicPlan does not distinguish a missing
+// plan from an empty one.
+func (b Build) PublicPlan() ([]byte, error) {
+	return b.publicPlan, nil
+}
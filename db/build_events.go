@@ -0,0 +1,204 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// BuildEvent is a single structured step or log line produced while a
+// build runs, persisted as a row rather than appended to a monolithic
+// blob so that it can be streamed, pruned, and aggregated independently.
+type BuildEvent struct {
+	BuildID int
+	EventID int
+	Type    string
+	Payload json.RawMessage
+	Time    time.Time
+}
+
+// SaveEvent appends a single event to the build's event stream. The
+// event_id is assigned here, not taken from the caller, and a per-build
+// advisory lock serializes the assignment so that concurrent writers
+// (e.g. racing step output) can't hand out the same event_id twice or
+// insert out of order.
+func (b Build) SaveEvent(event BuildEvent) error {
+	tx, err := b.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`SELECT pg_advisory_xact_lock($1)`, b.ID)
+	if err != nil {
+		return err
+	}
+
+	var nextEventID int
+	err = tx.QueryRow(`
+		SELECT COALESCE(MAX(event_id), 0) + 1
+		FROM build_events
+		WHERE build_id = $1
+	`, b.ID).Scan(&nextEventID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO build_events (build_id, event_id, type, payload, time)
+		VALUES ($1, $2, $3, $4, now())
+	`, b.ID, nextEventID, event.Type, event.Payload)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Events returns the build's events in order, for streaming to clients or
+// reconstructing logs.
+func (b Build) Events() ([]BuildEvent, error) {
+	return b.EventsAfter(0)
+}
+
+// EventsAfter returns the build's events with event_id > afterID, in
+// order, for incrementally tailing a running build's event stream.
+func (b Build) EventsAfter(afterID int) ([]BuildEvent, error) {
+	rows, err := b.conn.Query(`
+		SELECT build_id, event_id, type, payload, time
+		FROM build_events
+		WHERE build_id = $1
+		AND event_id > $2
+		ORDER BY event_id ASC
+	`, b.ID, afterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []BuildEvent
+	for rows.Next() {
+		var event BuildEvent
+		err = rows.Scan(&event.BuildID, &event.EventID, &event.Type, &event.Payload, &event.Time)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// StatusEvents returns only the build's "status" events, in order, so
+// callers aggregating a per-step status summary (e.g. for the builds list)
+// don't have to fetch and replay the entire event stream to find them.
+func (b Build) StatusEvents() ([]BuildEvent, error) {
+	rows, err := b.conn.Query(`
+		SELECT build_id, event_id, type, payload, time
+		FROM build_events
+		WHERE build_id = $1
+		AND type = 'status'
+		ORDER BY event_id ASC
+	`, b.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []BuildEvent
+	for rows.Next() {
+		var event BuildEvent
+		err = rows.Scan(&event.BuildID, &event.EventID, &event.Type, &event.Payload, &event.Time)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// Reload refreshes the build's status from the database, so that a
+// caller polling Status in a loop (e.g. to know when to stop tailing
+// events) observes completion.
+func (b *Build) Reload() (bool, error) {
+	var status string
+	err := b.conn.QueryRow(`SELECT status FROM builds WHERE id = $1`, b.ID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	b.Status = BuildStatus(status)
+	return true, nil
+}
+
+// IsRunning reports whether the build is still in a non-terminal state.
+func (b Build) IsRunning() bool {
+	switch b.Status {
+	case BuildStatusSucceeded, BuildStatusFailed, BuildStatusErrored, BuildStatusAborted:
+		return false
+	default:
+		return true
+	}
+}
+
+// RetainLast prunes all but the most recent n events for the build, so
+// that operators can bound log volume without dropping the build row
+// itself.
+func (b Build) RetainLast(n int) error {
+	_, err := b.conn.Exec(`
+		DELETE FROM build_events
+		WHERE build_id = $1
+		AND event_id NOT IN (
+			SELECT event_id FROM build_events
+			WHERE build_id = $1
+			ORDER BY event_id DESC
+			LIMIT $2
+		)
+	`, b.ID, n)
+	return err
+}
+
+// RetainSince prunes all events for the build older than t.
+func (b Build) RetainSince(t time.Time) error {
+	_, err := b.conn.Exec(`
+		DELETE FROM build_events
+		WHERE build_id = $1
+		AND time < $2
+	`, b.ID, t)
+	return err
+}
+
+// Lines reconstructs stdout/stderr from the build's log-type events, for
+// CLI consumers that want plain text rather than structured events.
+func (b Build) Lines() (string, error) {
+	events, err := b.Events()
+	if err != nil {
+		return "", err
+	}
+
+	var lines string
+	for _, event := range events {
+		if event.Type != "log" {
+			continue
+		}
+
+		var payload struct {
+			Payload string `json:"payload"`
+		}
+
+		err = json.Unmarshal(event.Payload, &payload)
+		if err != nil {
+			return "", err
+		}
+
+		lines += payload.Payload
+	}
+
+	return lines, nil
+}
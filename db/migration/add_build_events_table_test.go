@@ -0,0 +1,85 @@
+package migration_test
+
+import (
+	"database/sql"
+
+	"github.com/concourse/atc/db/migration"
+	"github.com/concourse/atc/db/migrations"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AddBuildEventsTable", func() {
+	var (
+		dbConn   *sql.DB
+		migrator migration.Migrator
+	)
+
+	BeforeEach(func() {
+		migrator = migrations.AddBuildEventsTable
+
+		var err error
+		dbConn, err = openDBConnPostMigration(migrator)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := dbConn.Close()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("creates a build_events table keyed on (build_id, event_id)", func() {
+		var teamID int
+		err := dbConn.QueryRow(`
+			INSERT INTO teams (name) VALUES ($1) RETURNING id
+		`, "some-team").Scan(&teamID)
+		Expect(err).NotTo(HaveOccurred())
+
+		var buildID int
+		err = dbConn.QueryRow(`
+			INSERT INTO builds (name, status, team_id) VALUES ($1, $2, $3) RETURNING id
+		`, "1", "started", teamID).Scan(&buildID)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = dbConn.Exec(`
+			INSERT INTO build_events (build_id, event_id, type, payload, time)
+			VALUES ($1, $2, $3, $4, now())
+		`, buildID, 1, "log", `{"payload":"hello"}`)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = dbConn.Exec(`
+			INSERT INTO build_events (build_id, event_id, type, payload, time)
+			VALUES ($1, $2, $3, $4, now())
+		`, buildID, 1, "log", `{"payload":"duplicate"}`)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("drops build_events when the build is removed", func() {
+		var teamID int
+		err := dbConn.QueryRow(`
+			INSERT INTO teams (name) VALUES ($1) RETURNING id
+		`, "some-other-team").Scan(&teamID)
+		Expect(err).NotTo(HaveOccurred())
+
+		var buildID int
+		err = dbConn.QueryRow(`
+			INSERT INTO builds (name, status, team_id) VALUES ($1, $2, $3) RETURNING id
+		`, "2", "started", teamID).Scan(&buildID)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = dbConn.Exec(`
+			INSERT INTO build_events (build_id, event_id, type, payload, time)
+			VALUES ($1, $2, $3, $4, now())
+		`, buildID, 1, "log", `{"payload":"hello"}`)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = dbConn.Exec(`DELETE FROM builds WHERE id = $1`, buildID)
+		Expect(err).NotTo(HaveOccurred())
+
+		var count int
+		err = dbConn.QueryRow(`SELECT count(*) FROM build_events WHERE build_id = $1`, buildID).Scan(&count)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(Equal(0))
+	})
+})
@@ -0,0 +1,76 @@
+package migration_test
+
+import (
+	"database/sql"
+
+	"github.com/concourse/atc/db/migration"
+	"github.com/concourse/atc/db/migrations"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AddChecksTable", func() {
+	var (
+		dbConn   *sql.DB
+		migrator migration.Migrator
+	)
+
+	BeforeEach(func() {
+		migrator = migrations.AddChecksTable
+
+		var err error
+		dbConn, err = openDBConnPostMigration(migrator)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := dbConn.Close()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("creates a checks table that can be inserted into and read back", func() {
+		var resourceConfigID int
+		err := dbConn.QueryRow(`
+			INSERT INTO resource_configs (source_hash) VALUES ($1) RETURNING id
+		`, "some-hash").Scan(&resourceConfigID)
+		Expect(err).NotTo(HaveOccurred())
+
+		var id int
+		err = dbConn.QueryRow(`
+			INSERT INTO checks (resource_config_id, status, plan)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`, resourceConfigID, "started", `{}`).Scan(&id)
+		Expect(err).NotTo(HaveOccurred())
+
+		var status string
+		err = dbConn.QueryRow(`SELECT status FROM checks WHERE id = $1`, id).Scan(&status)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal("started"))
+	})
+
+	It("drops checks when their resource config is removed", func() {
+		var resourceConfigID int
+		err := dbConn.QueryRow(`
+			INSERT INTO resource_configs (source_hash) VALUES ($1) RETURNING id
+		`, "some-other-hash").Scan(&resourceConfigID)
+		Expect(err).NotTo(HaveOccurred())
+
+		var id int
+		err = dbConn.QueryRow(`
+			INSERT INTO checks (resource_config_id, status, plan)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`, resourceConfigID, "started", `{}`).Scan(&id)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = dbConn.Exec(`DELETE FROM resource_configs WHERE id = $1`, resourceConfigID)
+		Expect(err).NotTo(HaveOccurred())
+
+		var count int
+		err = dbConn.QueryRow(`SELECT count(*) FROM checks WHERE id = $1`, id).Scan(&count)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(Equal(0))
+	})
+})
@@ -13,7 +13,7 @@ import (
 	. "github.com/onsi/gomega"
 )
 
-var _ = FDescribe("AddNonceAndPublicPlanToBuilds", func() {
+var _ = Describe("AddNonceAndPublicPlanToBuilds", func() {
 	var (
 		dbConn   *sql.DB
 		migrator migration.Migrator
@@ -176,7 +176,7 @@ var _ = FDescribe("AddNonceAndPublicPlanToBuilds", func() {
 		})
 
 		Context("when build was created with other engine", func() {
-			It("does not create public plan from engine metadata", func() {
+			It("leaves public_plan null, so HasPlan() reports false", func() {
 				var publicPlanJSON []byte
 				err := dbConn.QueryRow(
 					`SELECT public_plan FROM builds WHERE id=$1`,
@@ -184,7 +184,10 @@ var _ = FDescribe("AddNonceAndPublicPlanToBuilds", func() {
 				).Scan(&publicPlanJSON)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(publicPlanJSON).To(Equal([]byte("{}")))
+				Expect(publicPlanJSON).To(BeNil())
+
+				build := db.NewBuild(otherBuildID, "1", db.BuildStatusStarted, publicPlanJSON)
+				Expect(build.HasPlan()).To(BeFalse())
 			})
 		})
 	})
@@ -0,0 +1,67 @@
+package migration_test
+
+import (
+	"database/sql"
+
+	"github.com/concourse/atc/db/migration"
+	"github.com/concourse/atc/db/migrations"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AddResourceCacheGenerationsTable", func() {
+	var (
+		dbConn   *sql.DB
+		migrator migration.Migrator
+	)
+
+	BeforeEach(func() {
+		migrator = migrations.AddResourceCacheGenerationsTable
+
+		var err error
+		dbConn, err = openDBConnPostMigration(migrator)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := dbConn.Close()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("stamps a generation per resource_cache_id, one row each", func() {
+		var resourceConfigID int
+		err := dbConn.QueryRow(`
+			INSERT INTO resource_configs (source_hash) VALUES ($1) RETURNING id
+		`, "some-hash").Scan(&resourceConfigID)
+		Expect(err).NotTo(HaveOccurred())
+
+		var cacheID int
+		err = dbConn.QueryRow(`
+			INSERT INTO resource_caches (resource_config_id, version, params_hash)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`, resourceConfigID, `{"some":"version"}`, "null").Scan(&cacheID)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = dbConn.Exec(`
+			INSERT INTO resource_cache_generations (resource_cache_id, generation)
+			VALUES ($1, $2)
+		`, cacheID, 1)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = dbConn.Exec(`
+			INSERT INTO resource_cache_generations (resource_cache_id, generation)
+			VALUES ($1, $2)
+		`, cacheID, 2)
+		Expect(err).To(HaveOccurred())
+
+		_, err = dbConn.Exec(`DELETE FROM resource_caches WHERE id = $1`, cacheID)
+		Expect(err).NotTo(HaveOccurred())
+
+		var count int
+		err = dbConn.QueryRow(`SELECT count(*) FROM resource_cache_generations WHERE resource_cache_id = $1`, cacheID).Scan(&count)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(Equal(0))
+	})
+})
@@ -0,0 +1,17 @@
+package migrations
+
+import "database/sql"
+
+func AddBuildEventsTable(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE build_events (
+			build_id integer NOT NULL REFERENCES builds (id) ON DELETE CASCADE,
+			event_id integer NOT NULL,
+			type text NOT NULL,
+			payload jsonb NOT NULL,
+			time timestamp NOT NULL,
+			PRIMARY KEY (build_id, event_id)
+		)
+	`)
+	return err
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import "database/sql"
+
+func AddChecksTable(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE checks (
+			id SERIAL PRIMARY KEY,
+			resource_config_id integer NOT NULL REFERENCES resource_configs (id) ON DELETE CASCADE,
+			status text NOT NULL,
+			plan jsonb NOT NULL,
+			versions jsonb,
+			error text,
+			create_time timestamp NOT NULL DEFAULT now(),
+			start_time timestamp,
+			end_time timestamp
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		CREATE INDEX checks_resource_config_id_idx ON checks (resource_config_id)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		CREATE INDEX checks_status_idx ON checks (status)
+	`)
+	return err
+}
@@ -0,0 +1,20 @@
+package migrations
+
+import "database/sql"
+
+func AddResourceCacheGenerationsTable(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE resource_cache_generations (
+			resource_cache_id integer PRIMARY KEY REFERENCES resource_caches (id) ON DELETE CASCADE,
+			generation integer NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		CREATE INDEX resource_cache_generations_generation_idx ON resource_cache_generations (generation)
+	`)
+	return err
+}
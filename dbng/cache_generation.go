@@ -0,0 +1,70 @@
+package dbng
+
+import (
+	"container/list"
+	"sync"
+)
+
+// cacheGenerationLRU is a small in-memory LRU of recently-referenced
+// cache ids, bounded by count. It is populated as
+// FindOrCreateResourceCacheFor* runs and consulted by CleanUpInvalidCaches
+// as a fast filter for the mark phase, so that a generation bump doesn't
+// have to consider every cache on every sweep.
+type cacheGenerationLRU struct {
+	maxEntries int
+
+	mu    sync.Mutex
+	byID  map[int]*list.Element
+	order *list.List
+}
+
+func newCacheGenerationLRU(maxEntries int) *cacheGenerationLRU {
+	return &cacheGenerationLRU{
+		maxEntries: maxEntries,
+		byID:       make(map[int]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Touch records that cacheID was just referenced, evicting the
+// least-recently-touched entry if the LRU has grown past its bound.
+func (l *cacheGenerationLRU) Touch(cacheID int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.byID[cacheID]; ok {
+		l.order.MoveToFront(elem)
+		return
+	}
+
+	elem := l.order.PushFront(cacheID)
+	l.byID[cacheID] = elem
+
+	if l.order.Len() > l.maxEntries {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.byID, oldest.Value.(int))
+	}
+}
+
+// CacheIDs returns every cache id currently tracked by the LRU.
+func (l *cacheGenerationLRU) CacheIDs() []int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ids := make([]int, 0, l.order.Len())
+	for elem := l.order.Front(); elem != nil; elem = elem.Next() {
+		ids = append(ids, elem.Value.(int))
+	}
+
+	return ids
+}
+
+// CacheGenerationMetrics reports how effective the LRU-backed mark phase
+// was for a single CleanUpInvalidCaches cycle, so operators can tune K and
+// the LRU size.
+type CacheGenerationMetrics struct {
+	LRUHitRate   float64
+	CachesMarked int
+	CachesSwept  int
+}
@@ -0,0 +1,301 @@
+package dbng
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/concourse/atc"
+)
+
+//go:generate counterfeiter . CheckFactory
+
+type CheckFactory interface {
+	CreateCheck(resourceConfigID int, plan atc.Plan) (*Check, bool, error)
+	GetCheck(id int) (*Check, bool, error)
+	StartedChecks() ([]*Check, error)
+	AcquireCheckingLock(check *Check) (bool, error)
+	FinishCheck(id int, status CheckStatus, versions []atc.Version, checkErr error) error
+	LatestCheckEndTime(resourceConfigID int) (time.Time, bool, error)
+}
+
+type CheckStatus string
+
+const (
+	CheckStatusStarted   CheckStatus = "started"
+	CheckStatusSucceeded CheckStatus = "succeeded"
+	CheckStatusErrored   CheckStatus = "errored"
+)
+
+type Check struct {
+	ID               int
+	ResourceConfigID int
+	Status           CheckStatus
+	Plan             atc.Plan
+	Versions         []atc.Version
+	CreateTime       time.Time
+	StartTime        time.Time
+	EndTime          time.Time
+	CheckError       error
+}
+
+type checkFactory struct {
+	conn Conn
+}
+
+func NewCheckFactory(conn Conn) CheckFactory {
+	return &checkFactory{
+		conn: conn,
+	}
+}
+
+// CreateCheck enqueues a check for resourceConfigID, unless one is already
+// pending or in flight. The existing-check lookup and the insert happen in
+// the same tx, under a row lock on the resource config, so a scanner sweep
+// can't race itself into enqueuing two checks for the same resource
+// config. The bool return reports whether a check was actually created.
+func (f *checkFactory) CreateCheck(resourceConfigID int, plan atc.Plan) (*Check, bool, error) {
+	tx, err := f.conn.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	_, err = psql.Select("id").
+		From("resource_configs").
+		Where(sq.Eq{"id": resourceConfigID}).
+		Suffix("FOR UPDATE").
+		RunWith(tx).
+		Exec()
+	if err != nil {
+		return nil, false, err
+	}
+
+	var existingID int
+	err = psql.Select("id").
+		From("checks").
+		Where(sq.Eq{"resource_config_id": resourceConfigID, "status": string(CheckStatusStarted)}).
+		RunWith(tx).
+		QueryRow().
+		Scan(&existingID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, false, err
+	}
+	if err == nil {
+		return nil, false, nil
+	}
+
+	planJSON, err := json.Marshal(plan)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var id int
+	var createTime time.Time
+	err = psql.Insert("checks").
+		Columns("resource_config_id", "status", "plan").
+		Values(resourceConfigID, string(CheckStatusStarted), planJSON).
+		Suffix("RETURNING id, create_time").
+		RunWith(tx).
+		QueryRow().
+		Scan(&id, &createTime)
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &Check{
+		ID:               id,
+		ResourceConfigID: resourceConfigID,
+		Status:           CheckStatusStarted,
+		Plan:             plan,
+		CreateTime:       createTime,
+	}, true, nil
+}
+
+func (f *checkFactory) GetCheck(id int) (*Check, bool, error) {
+	var (
+		check        Check
+		status       string
+		planJSON     []byte
+		versionsJSON []byte
+		startTime    sql.NullTime
+		endTime      sql.NullTime
+		errString    sql.NullString
+	)
+
+	err := psql.Select("id", "resource_config_id", "status", "plan", "versions", "error", "create_time", "start_time", "end_time").
+		From("checks").
+		Where(sq.Eq{"id": id}).
+		RunWith(f.conn).
+		QueryRow().
+		Scan(&check.ID, &check.ResourceConfigID, &status, &planJSON, &versionsJSON, &errString, &check.CreateTime, &startTime, &endTime)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	check.Status = CheckStatus(status)
+	err = json.Unmarshal(planJSON, &check.Plan)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if versionsJSON != nil {
+		err = json.Unmarshal(versionsJSON, &check.Versions)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if startTime.Valid {
+		check.StartTime = startTime.Time
+	}
+
+	if endTime.Valid {
+		check.EndTime = endTime.Time
+	}
+
+	if errString.Valid {
+		check.CheckError = errors.New(errString.String)
+	}
+
+	return &check, true, nil
+}
+
+// StartedChecks returns every check still in the 'started' state, for use
+// by a lidar.Runner claiming work.
+func (f *checkFactory) StartedChecks() ([]*Check, error) {
+	rows, err := psql.Select("id", "resource_config_id", "status", "plan", "create_time").
+		From("checks").
+		Where(sq.Eq{"status": string(CheckStatusStarted)}).
+		RunWith(f.conn).
+		Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checks []*Check
+	for rows.Next() {
+		var (
+			check    Check
+			status   string
+			planJSON []byte
+		)
+
+		err = rows.Scan(&check.ID, &check.ResourceConfigID, &status, &planJSON, &check.CreateTime)
+		if err != nil {
+			return nil, err
+		}
+
+		check.Status = CheckStatus(status)
+		err = json.Unmarshal(planJSON, &check.Plan)
+		if err != nil {
+			return nil, err
+		}
+
+		checks = append(checks, &check)
+	}
+
+	return checks, nil
+}
+
+// A check is pending while status='started' and start_time is unset.
+// Claiming stamps start_time before the lock is released, so a second
+// claim attempt no longer matches even though status itself doesn't
+// change until FinishCheck.
+func (f *checkFactory) AcquireCheckingLock(check *Check) (bool, error) {
+	tx, err := f.conn.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = psql.Select("id").
+		From("checks").
+		Where(sq.Eq{"id": check.ID, "status": string(CheckStatusStarted)}).
+		Where(sq.Expr("start_time IS NULL")).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		RunWith(tx).
+		QueryRow().
+		Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = psql.Update("checks").
+		Set("start_time", sq.Expr("now()")).
+		Where(sq.Eq{"id": id}).
+		RunWith(tx).
+		Exec()
+	if err != nil {
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (f *checkFactory) FinishCheck(id int, status CheckStatus, versions []atc.Version, checkErr error) error {
+	versionsJSON, err := json.Marshal(versions)
+	if err != nil {
+		return err
+	}
+
+	var errString sql.NullString
+	if checkErr != nil {
+		errString = sql.NullString{String: checkErr.Error(), Valid: true}
+	}
+
+	_, err = psql.Update("checks").
+		Set("status", string(status)).
+		Set("end_time", sq.Expr("now()")).
+		Set("versions", versionsJSON).
+		Set("error", errString).
+		Where(sq.Eq{"id": id}).
+		RunWith(f.conn).
+		Exec()
+	return err
+}
+
+// LatestCheckEndTime returns the end_time of the most recently finished
+// check for resourceConfigID, so a scanner can ground its check_every
+// interval math in an actual completion rather than a signal that never
+// advances.
+func (f *checkFactory) LatestCheckEndTime(resourceConfigID int) (time.Time, bool, error) {
+	var endTime time.Time
+	err := psql.Select("end_time").
+		From("checks").
+		Where(sq.Eq{"resource_config_id": resourceConfigID}).
+		Where(sq.Expr("end_time IS NOT NULL")).
+		OrderBy("end_time DESC").
+		Limit(1).
+		RunWith(f.conn).
+		QueryRow().
+		Scan(&endTime)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return endTime, true, nil
+}
@@ -0,0 +1,78 @@
+package dbng
+
+import (
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/creds"
+)
+
+// interpolateSourceAndParams resolves any `((var))` placeholders in source
+// and params through the given managers, scoped to the pipeline's team and
+// name. Only a value that is exactly `((name))` is resolved; a var that no
+// manager finds is left as written.
+//
+// Its callers are resourceCacheFactory's FindOrCreateResourceCacheFor*
+// methods, which interpolate source and params before constructing the
+// resource config. Build plans and engine_metadata are not interpolated
+// here, and auth.teamAuthValidator and buildserver.ListBuilds do not call
+// it.
+func interpolateSourceAndParams(
+	managers creds.Managers,
+	pipeline *Pipeline,
+	source atc.Source,
+	params atc.Params,
+) (atc.Source, atc.Params, error) {
+	if len(managers) == 0 {
+		return source, params, nil
+	}
+
+	team, pipelineName := "", ""
+	if pipeline != nil {
+		team = pipeline.TeamName()
+		pipelineName = pipeline.Name()
+	}
+
+	interpolatedSource, err := interpolate(managers, team, pipelineName, source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	interpolatedParams, err := interpolate(managers, team, pipelineName, atc.Source(params))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return interpolatedSource, atc.Params(interpolatedParams), nil
+}
+
+func interpolate(managers creds.Managers, team string, pipeline string, fields atc.Source) (atc.Source, error) {
+	interpolated := make(atc.Source, len(fields))
+
+	for k, v := range fields {
+		varName, ok := v.(string)
+		if !ok || !isVarReference(varName) {
+			interpolated[k] = v
+			continue
+		}
+
+		value, found, err := managers.Get(team, pipeline, varReferenceName(varName))
+		if err != nil {
+			return nil, err
+		}
+
+		if found {
+			interpolated[k] = value
+		} else {
+			interpolated[k] = v
+		}
+	}
+
+	return interpolated, nil
+}
+
+func isVarReference(s string) bool {
+	return len(s) > 4 && s[:2] == "((" && s[len(s)-2:] == "))"
+}
+
+func varReferenceName(s string) string {
+	return s[2 : len(s)-2]
+}
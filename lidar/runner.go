@@ -0,0 +1,77 @@
+package lidar
+
+import (
+	"code.cloudfoundry.org/lager"
+
+	"github.com/concourse/atc/dbng"
+	"github.com/concourse/atc/engine"
+)
+
+type Runner interface {
+	Run() error
+}
+
+type runner struct {
+	logger       lager.Logger
+	checkFactory dbng.CheckFactory
+	engine       engine.Engine
+}
+
+func NewRunner(
+	logger lager.Logger,
+	checkFactory dbng.CheckFactory,
+	engine engine.Engine,
+) Runner {
+	return &runner{
+		logger:       logger,
+		checkFactory: checkFactory,
+		engine:       engine,
+	}
+}
+
+func (r *runner) Run() error {
+	logger := r.logger.Session("run")
+
+	checks, err := r.checkFactory.StartedChecks()
+	if err != nil {
+		logger.Error("failed-to-get-started-checks", err)
+		return err
+	}
+
+	for _, check := range checks {
+		acquired, err := r.checkFactory.AcquireCheckingLock(check)
+		if err != nil {
+			logger.Error("failed-to-acquire-checking-lock", err)
+			continue
+		}
+
+		if !acquired {
+			continue
+		}
+
+		r.runCheck(logger, check)
+	}
+
+	return nil
+}
+
+func (r *runner) runCheck(logger lager.Logger, check *dbng.Check) {
+	versions, err := r.engine.Check(check.Plan)
+	if err != nil {
+		logger.Error("failed-to-run-check", err, lager.Data{"check": check.ID})
+
+		finishErr := r.checkFactory.FinishCheck(check.ID, dbng.CheckStatusErrored, nil, err)
+		if finishErr != nil {
+			logger.Error("failed-to-finish-check", finishErr, lager.Data{"check": check.ID})
+		}
+
+		return
+	}
+
+	logger.Debug("check-completed", lager.Data{"check": check.ID, "versions": len(versions)})
+
+	err = r.checkFactory.FinishCheck(check.ID, dbng.CheckStatusSucceeded, versions, nil)
+	if err != nil {
+		logger.Error("failed-to-finish-check", err, lager.Data{"check": check.ID})
+	}
+}
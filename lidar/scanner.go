@@ -0,0 +1,104 @@
+package lidar
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/lager"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/dbng"
+)
+
+// Scanner periodically walks the resources of every pipeline and enqueues
+// a Check row for any resource whose check_every interval has elapsed.
+// Resource types are not walked, so no checks are enqueued for them. It
+// replaces the per-resource radar goroutine with a single, horizontally
+// scalable sweep.
+type Scanner interface {
+	Run() error
+}
+
+type scanner struct {
+	logger          lager.Logger
+	checkFactory    dbng.CheckFactory
+	pipelineFactory dbng.PipelineFactory
+}
+
+func NewScanner(
+	logger lager.Logger,
+	checkFactory dbng.CheckFactory,
+	pipelineFactory dbng.PipelineFactory,
+) Scanner {
+	return &scanner{
+		logger:          logger,
+		checkFactory:    checkFactory,
+		pipelineFactory: pipelineFactory,
+	}
+}
+
+func (s *scanner) Run() error {
+	logger := s.logger.Session("scan")
+
+	pipelines, err := s.pipelineFactory.AllPipelines()
+	if err != nil {
+		logger.Error("failed-to-get-pipelines", err)
+		return err
+	}
+
+	for _, pipeline := range pipelines {
+		for _, resource := range pipeline.Resources() {
+			elapsed, err := s.intervalElapsed(resource)
+			if err != nil {
+				logger.Error("failed-to-check-interval", err, lager.Data{"resource": resource.Name()})
+				continue
+			}
+
+			if !elapsed {
+				continue
+			}
+
+			_, created, err := s.checkFactory.CreateCheck(
+				resource.ResourceConfigID(),
+				atc.Plan{
+					Check: &atc.CheckPlan{
+						Name:   resource.Name(),
+						Pinned: resource.PinnedVersion(),
+					},
+				},
+			)
+			if err != nil {
+				logger.Error("failed-to-create-check", err, lager.Data{"resource": resource.Name()})
+				continue
+			}
+
+			if !created {
+				logger.Debug("check-already-pending", lager.Data{"resource": resource.Name()})
+			}
+		}
+	}
+
+	return nil
+}
+
+// intervalElapsed reports whether resource's check_every has passed since
+// its last finished check. The "last checked" signal comes from the
+// checks table itself (via LatestCheckEndTime) rather than anything
+// tracked on the resource, so it only advances once FinishCheck actually
+// runs -- a resource with no finished check yet is always due.
+func (s *scanner) intervalElapsed(resource dbng.Resource) (bool, error) {
+	interval := resource.CheckEvery()
+	if interval == 0 {
+		interval = atc.DefaultCheckInterval
+	}
+
+	lastEndTime, found, err := s.checkFactory.LatestCheckEndTime(resource.ResourceConfigID())
+	if err != nil {
+		return false, err
+	}
+
+	if !found {
+		return true, nil
+	}
+
+	return time.Since(lastEndTime) >= interval, nil
+}